@@ -0,0 +1,291 @@
+package auth0grant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	josepkg "github.com/go-jose/go-jose/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// Claims holds the typed subset of an Auth0 access token's claims that callers most often need, alongside the full claim set in Raw for anything else.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+	NotBefore time.Time
+
+	// Scope is Auth0's space-delimited OAuth2 scope claim.
+	Scope string
+
+	// Permissions lists the RBAC permissions Auth0 attached to the token, if role-based access control is enabled for the API.
+	Permissions []string
+
+	// Gty is Auth0's non-standard grant-type claim; for a client_credentials grant it is "client-credentials".
+	Gty string
+
+	// Azp is the authorized party: the client ID that requested the token.
+	Azp string
+
+	// Raw holds every claim Auth0 returned, including any not represented above.
+	Raw map[string]interface{}
+}
+
+// Claims parses, but does not cryptographically verify, the claims of the Grant's current access token. Use VerifiedClaims if you need to trust the result (e.g. to make an authorization decision); Claims is meant for logging or debugging.
+func (g *grantRequest) Claims() (map[string]interface{}, error) {
+	token, err := g.token(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "get access token")
+	}
+
+	return parseUnverifiedClaims(token.AccessToken)
+}
+
+// VerifiedClaims parses the Grant's current access token, verifies its signature against jwks, validates the standard iss/aud/exp/nbf claims, and returns the result as a Claims. Use a JWKSCache against the tenant's https://<tenant>/.well-known/jwks.json to build jwks.
+func (g *grantRequest) VerifiedClaims(jwks josepkg.JSONWebKeySet) (*Claims, error) {
+	token, err := g.token(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "get access token")
+	}
+
+	sig, err := josepkg.ParseSigned(token.AccessToken, []josepkg.SignatureAlgorithm{josepkg.RS256, josepkg.ES256})
+	if err != nil {
+		return nil, errors.Wrap(err, "parse jwt")
+	}
+	if len(sig.Signatures) != 1 {
+		return nil, errors.New("expected exactly one jws signature on access token")
+	}
+
+	kid := sig.Signatures[0].Header.KeyID
+	key := lookupKid(jwks, kid)
+	if key == nil {
+		return nil, errors.Errorf("no jwks key with kid %q", kid)
+	}
+
+	payload, err := sig.Verify(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "verify jwt signature")
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, errors.Wrap(err, "decode jwt claims")
+	}
+
+	claims := claimsFromRaw(raw)
+
+	if err := g.validateClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (g *grantRequest) validateClaims(claims *Claims, now time.Time) error {
+	if !containsString(claims.Audience, g.audience) {
+		return errors.Errorf("jwt aud claim %v does not contain expected audience %q", claims.Audience, g.audience)
+	}
+	if claims.Issuer != g.issuer {
+		return errors.Errorf("jwt iss claim got %q, expected %q", claims.Issuer, g.issuer)
+	}
+	if !claims.ExpiresAt.IsZero() && now.After(claims.ExpiresAt) {
+		return errors.New("jwt is expired")
+	}
+	if !claims.NotBefore.IsZero() && now.Before(claims.NotBefore) {
+		return errors.New("jwt is not valid yet")
+	}
+
+	return nil
+}
+
+// parseUnverifiedClaims decodes the payload segment of a JWT without checking its signature.
+func parseUnverifiedClaims(tokenString string) (map[string]interface{}, error) {
+	sig, err := josepkg.ParseSigned(tokenString, []josepkg.SignatureAlgorithm{josepkg.RS256, josepkg.ES256})
+	if err != nil {
+		return nil, errors.Wrap(err, "parse jwt")
+	}
+	if len(sig.Signatures) != 1 {
+		return nil, errors.New("expected exactly one jws signature on access token")
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(sig.UnsafePayloadWithoutVerification(), &claims); err != nil {
+		return nil, errors.Wrap(err, "decode jwt claims")
+	}
+
+	return claims, nil
+}
+
+func claimsFromRaw(raw map[string]interface{}) *Claims {
+	claims := &Claims{Raw: raw}
+
+	if issuer, ok := raw["iss"].(string); ok {
+		claims.Issuer = issuer
+	}
+	if subject, ok := raw["sub"].(string); ok {
+		claims.Subject = subject
+	}
+	claims.Audience = stringsClaim(raw["aud"])
+	claims.ExpiresAt = timeClaim(raw["exp"])
+	claims.IssuedAt = timeClaim(raw["iat"])
+	claims.NotBefore = timeClaim(raw["nbf"])
+	if scope, ok := raw["scope"].(string); ok {
+		claims.Scope = scope
+	}
+	claims.Permissions = stringsClaim(raw["permissions"])
+	if gty, ok := raw["gty"].(string); ok {
+		claims.Gty = gty
+	}
+	if azp, ok := raw["azp"].(string); ok {
+		claims.Azp = azp
+	}
+
+	return claims
+}
+
+// stringsClaim normalizes a claim that Auth0 may encode as either a single string or an array of strings (e.g. "aud").
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func timeClaim(value interface{}) time.Time {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(seconds), 0)
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+
+	return false
+}
+
+// alignExpiryToClaims overrides token.Expiry with the access token's own exp claim, when one parses. Auth0's expires_in is relative to Auth0's clock; if there's skew between that and the caller's clock, trusting expires_in (as oauth2 does by default) can make GetAccessToken serve a token that's already expired by the time it reaches the API it authorizes. Reading exp directly avoids that.
+func alignExpiryToClaims(token *oauth2.Token) {
+	claims, err := parseUnverifiedClaims(token.AccessToken)
+	if err != nil {
+		return
+	}
+
+	if exp := timeClaim(claims["exp"]); !exp.IsZero() {
+		token.Expiry = exp
+	}
+}
+
+// JWKSCache fetches and caches a tenant's JSON Web Key Set (typically https://<tenant>/.well-known/jwks.json) for use with Grant.VerifiedClaims. It honors ETag so repeat fetches are cheap, and refreshes automatically if asked for a kid it doesn't recognize yet.
+type JWKSCache struct {
+	URL        string
+	HTTPClient *http.Client
+
+	mu   sync.Mutex
+	etag string
+	keys josepkg.JSONWebKeySet
+}
+
+// NewJWKSCache returns a JWKSCache for the JWKS at url.
+func NewJWKSCache(url string) *JWKSCache {
+	return &JWKSCache{URL: url, HTTPClient: http.DefaultClient}
+}
+
+// Get returns the cached key set, fetching it first if this is the first call.
+func (c *JWKSCache) Get(ctx context.Context) (josepkg.JSONWebKeySet, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.keys.Keys) == 0 {
+		if err := c.refreshLocked(ctx); err != nil {
+			return josepkg.JSONWebKeySet{}, err
+		}
+	}
+
+	return c.keys, nil
+}
+
+// Key returns the key with the given kid, refreshing the cache first if kid isn't already known.
+func (c *JWKSCache) Key(ctx context.Context, kid string) (*josepkg.JSONWebKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key := lookupKid(c.keys, kid); key != nil {
+		return key, nil
+	}
+
+	if err := c.refreshLocked(ctx); err != nil {
+		return nil, err
+	}
+
+	if key := lookupKid(c.keys, kid); key != nil {
+		return key, nil
+	}
+
+	return nil, errors.Errorf("jwks: no key with kid %q", kid)
+}
+
+func (c *JWKSCache) refreshLocked(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return errors.Wrap(err, "build jwks request")
+	}
+	if c.etag != "" {
+		req.Header.Set("If-None-Match", c.etag)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "fetch jwks")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("fetch jwks: unexpected status %s", resp.Status)
+	}
+
+	var keys josepkg.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return errors.Wrap(err, "decode jwks")
+	}
+
+	c.keys = keys
+	c.etag = resp.Header.Get("ETag")
+
+	return nil
+}
+
+func lookupKid(keys josepkg.JSONWebKeySet, kid string) *josepkg.JSONWebKey {
+	for i := range keys.Keys {
+		if keys.Keys[i].KeyID == kid {
+			return &keys.Keys[i]
+		}
+	}
+
+	return nil
+}
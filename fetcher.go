@@ -0,0 +1,87 @@
+package auth0grant
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// tokenFetcher performs a single, uncached token request against Auth0. Swapping the fetcher a Grant uses swaps its client-authentication method, while the caching, retry, refresh-leeway, and TokenStore behavior in grantRequest stays the same.
+type tokenFetcher interface {
+	fetch(ctx context.Context, httpClient *http.Client) (*oauth2.Token, error)
+}
+
+// clientSecretFetcher authenticates with a shared client_secret, via clientcredentials.Config.
+type clientSecretFetcher struct {
+	config clientcredentials.Config
+}
+
+func newClientSecretFetcher(tokenURL string, credRequest *CredentialsRequest) *clientSecretFetcher {
+	return &clientSecretFetcher{
+		config: clientcredentials.Config{
+			ClientID:     credRequest.ClientID,
+			ClientSecret: credRequest.ClientSecret,
+			TokenURL:     tokenURL,
+			EndpointParams: url.Values{
+				"audience": {credRequest.Audience},
+			},
+			// Auth0 M2M applications send client_id/client_secret form-encoded in the POST body by default. Leaving AuthStyle at its zero value instead makes golang.org/x/oauth2 probe: it tries HTTP Basic auth first and only falls back to the POST body (with an extra round-trip) if that's rejected.
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+// WithAuthStyle overrides how a Grant constructed by NewGrant sends client_id/client_secret to Auth0: oauth2.AuthStyleInParams (the default, form-encoded in the POST body) or oauth2.AuthStyleInHeader (HTTP Basic auth), for tenants configured to require the latter. It has no effect on a Grant constructed by NewGrantWithPrivateKeyJWT, which doesn't authenticate with a client secret.
+func WithAuthStyle(style oauth2.AuthStyle) GrantOption {
+	return func(g *grantRequest) {
+		if f, ok := g.fetcher.(*clientSecretFetcher); ok {
+			f.config.AuthStyle = style
+		}
+	}
+}
+
+func (f *clientSecretFetcher) fetch(ctx context.Context, httpClient *http.Client) (*oauth2.Token, error) {
+	capture := &errorCapturingTransport{base: httpClient.Transport}
+	if capture.base == nil {
+		capture.base = http.DefaultTransport
+	}
+
+	client := *httpClient
+	client.Transport = capture
+
+	token, err := f.config.Token(context.WithValue(ctx, oauth2.HTTPClient, &client))
+	if err != nil && capture.authErr != nil {
+		return nil, capture.authErr
+	}
+
+	return token, err
+}
+
+// errorCapturingTransport records Auth0's raw error response body so toAuthError can build an *AuthError straight from it, the same way authErrorFromResponse does for the private_key_jwt fetcher. clientcredentials.Config.Token decides how to parse a response by its Content-Type header, and falls back to treating the body as a form-encoded query string whenever that header is missing or wrong (which Go's net/http content-sniffs into for any handler that doesn't set it explicitly) - silently losing Auth0's {"error": ..., "error_description": ...} JSON envelope in the process. Parsing the body ourselves, independent of Content-Type, avoids that.
+type errorCapturingTransport struct {
+	base    http.RoundTripper
+	authErr *AuthError
+}
+
+func (t *errorCapturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if readErr != nil {
+		return resp, err
+	}
+
+	t.authErr = authErrorFromResponse(resp.StatusCode, body)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return resp, err
+}
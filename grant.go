@@ -2,7 +2,7 @@
 //
 // A client_credentials grant uses your machine-to-machine app's ID and secret to obtain a JWT bearer token that authorizes your program to your API, and maybe more (i.e. scopes, claims, or whatever you configure). The access token has an expiration that you can manage in the Auth0 dashboard for your API.
 //
-// This library provides a simple interface that gives you the access token when you need it and transparently re-requests it when it expires.
+// This library is built around golang.org/x/oauth2: a Grant returned by NewGrant is backed by a clientcredentials.Config (one returned by NewGrantWithPrivateKeyJWT posts its own form-encoded token request instead), and every Grant hands out an oauth2.TokenSource (or a ready-to-use *http.Client) that can be dropped into anything already written against that ecosystem, such as oauth2.NewClient, gRPC per-RPC credentials, or the google.golang.org/api client libraries. GetAccessToken remains available as a convenience for callers who just want the bearer token string and transparently re-requests it when it expires.
 //
 // For more information, see:
 //
@@ -13,104 +13,218 @@
 package auth0grant
 
 import (
-	"bytes"
-	"encoding/json"
-	"io/ioutil"
+	"context"
 	"net/http"
+	"net/url"
 	"time"
 
+	josepkg "github.com/go-jose/go-jose/v4"
 	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
 )
 
 // CredentialsRequest contains the information needed to request credentials from the Auth0 authorization server.
 type CredentialsRequest struct {
 	// ClientID is the Auth0 Client ID string for the M2M application
-	ClientID string `json:"client_id"`
+	ClientID string
 
 	// ClientSecret is the Auth0 Client Secret string for the M2M application
-	ClientSecret string `json:"client_secret"`
+	ClientSecret string
 
 	// Audience identifies the audience of the access token
-	Audience string `json:"audience"`
+	Audience string
 
-	// GrantType should always be "client_credentials" (CLIENT_CREDS_GRANT_TYPE)
-	GrantType string `json:"grant_type"`
+	// GrantType is vestigial: Auth0 client_credentials grants only ever use CLIENT_CREDS_GRANT_TYPE, and NewGrant no longer inspects this field. It is kept so existing callers that set it do not break.
+	GrantType string
 }
 
-// CLIENT_CREDS_GRANT_TYPE is what you should set the GrantType of a credentials request to
+// CLIENT_CREDS_GRANT_TYPE is the only grant type Auth0 supports for machine-to-machine applications.
 const CLIENT_CREDS_GRANT_TYPE = "client_credentials"
 
-type clientCredsGrant struct {
-	AccessToken string        `json:"access_token"`
-	Scope       string        `json:"scope"`
-	ExpiresIn   time.Duration `json:"expires_in"`
-	TokenType   string        `json:"token_type"`
+// DefaultRefreshLeeway is the RefreshLeeway a Grant uses when NewGrant is not given a WithRefreshLeeway option.
+const DefaultRefreshLeeway = 60 * time.Second
+
+// Grant obtains and caches credentials from the Auth0 authorization server. Use GetAccessToken to obtain those credentials in string token form, or TokenSource/Client to plug this grant into code that is already written against golang.org/x/oauth2.
+type Grant interface {
+	// GetAccessToken returns the credentials obtained from the Auth0 authorization server in string token form. You should immediately use the token returned by this function only once (it will expire), and obtain a new one each time you need to authenticate. If the cached access token is within its RefreshLeeway of expiring, GetAccessToken renews it first; otherwise, a cached copy is returned.
+	GetAccessToken() (string, error)
+
+	// TokenSource returns an oauth2.TokenSource backed by this grant. The returned source shares this grant's cache, so it is safe to call Token() on it as often as you like, and from as many goroutines as you like.
+	TokenSource(ctx context.Context) oauth2.TokenSource
+
+	// Client returns an *http.Client that attaches a valid access token to every outgoing request via the Authorization header, renewing it as needed.
+	Client(ctx context.Context) *http.Client
+
+	// StartAutoRefresh runs a background goroutine that proactively renews the cached token shortly before it expires, so request-path calls to GetAccessToken, TokenSource, and Client never have to pay for a token fetch themselves. It returns immediately; the goroutine exits once ctx is done.
+	StartAutoRefresh(ctx context.Context)
+
+	// Claims parses, but does not cryptographically verify, the claims of the current access token. It's meant for logging or debugging; use VerifiedClaims if the result needs to be trusted.
+	Claims() (map[string]interface{}, error)
+
+	// VerifiedClaims parses the current access token, verifies its signature against jwks, validates the standard iss/aud/exp/nbf claims, and returns the result. Build jwks from the tenant's https://<tenant>/.well-known/jwks.json, e.g. via a JWKSCache.
+	VerifiedClaims(jwks josepkg.JSONWebKeySet) (*Claims, error)
 }
 
-// Grant contains the credentials obtained from the Auth0 authorization server. Use the AccessToken method to obtain these credentials in string token form to authorize this application with your secured resources (i.e. secure API).
-type Grant struct {
-	grant       *clientCredsGrant
-	issuedAt    time.Time
-	tokenURL    string
-	credRequest CredentialsRequest
-	nowFn       func() time.Time
+type grantRequest struct {
+	fetcher       tokenFetcher
+	clientID      string
+	audience      string
+	issuer        string
+	refreshLeeway time.Duration
+	store         TokenStore
+	retryPolicy   RetryPolicy
+	httpClient    *http.Client
+	clock         Clock
+
+	sf singleflight.Group
 }
 
-// NewGrant obtains credentials from the Auth0 authorization server for your application. The tokenURL is the URL of your Auth0 tenant, usually followed by "/oauth/token/". The credRequest struct should be filled in with the inputs needed to authenticate and request credentials from the Auth0 authorization server.
-func NewGrant(tokenURL string, credRequest CredentialsRequest) *Grant {
-	return &Grant{
-		tokenURL:    tokenURL,
-		credRequest: credRequest,
-		nowFn:       time.Now,
+// GrantOption customizes a Grant constructed by NewGrant.
+type GrantOption func(*grantRequest)
+
+// WithRefreshLeeway overrides DefaultRefreshLeeway: a cached token is renewed once it is this close to expiring, rather than only once it has actually expired.
+func WithRefreshLeeway(leeway time.Duration) GrantOption {
+	return func(g *grantRequest) {
+		g.refreshLeeway = leeway
 	}
 }
 
-// GetAccessToken returns the credentials obtained from the Auth0 authorization server in string token form. You should immediately use the token returned by this function only once (it will expire), and obtain a new one each time you need to authenticate. If the access token is expired, GetAccessToken will request a new one; otherwise, a cached copy is returned.
-func (g *Grant) GetAccessToken() (string, error) {
-	if g.needsRenew() {
-		if err := g.renewGrant(); err != nil {
-			return "", errors.Wrap(err, "renew grant")
-		}
+// NewGrant obtains credentials from the Auth0 authorization server for your application. The tokenURL is the URL of your Auth0 tenant, usually followed by "/oauth/token". The credRequest struct should be filled in with the inputs needed to authenticate and request credentials from the Auth0 authorization server.
+func NewGrant(tokenURL string, credRequest *CredentialsRequest, opts ...GrantOption) Grant {
+	return newGrant(newClientSecretFetcher(tokenURL, credRequest), tokenURL, credRequest.ClientID, credRequest.Audience, opts)
+}
+
+func newGrant(fetcher tokenFetcher, tokenURL, clientID, audience string, opts []GrantOption) Grant {
+	g := &grantRequest{
+		fetcher:       fetcher,
+		clientID:      clientID,
+		audience:      audience,
+		issuer:        issuerFromTokenURL(tokenURL),
+		refreshLeeway: DefaultRefreshLeeway,
+		store:         &memoryTokenStore{},
+		retryPolicy:   DefaultRetryPolicy,
+		httpClient:    http.DefaultClient,
+		clock:         realClock{},
+	}
+
+	for _, opt := range opts {
+		opt(g)
 	}
 
-	return g.grant.AccessToken, nil
+	return g
 }
 
-func (g *Grant) renewGrant() error {
-	payload, err := json.Marshal(g.credRequest)
+// issuerFromTokenURL derives the "iss" claim Auth0 puts on its tokens (https://<tenant>/) from the /oauth/token URL used to request them.
+func issuerFromTokenURL(tokenURL string) string {
+	u, err := url.Parse(tokenURL)
 	if err != nil {
-		return errors.Wrap(err, "json encode cred request")
+		return ""
 	}
 
-	resp, err := http.Post(g.tokenURL, "application/json", bytes.NewBuffer(payload))
+	u.Path = "/"
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return u.String()
+}
+
+func (g *grantRequest) GetAccessToken() (string, error) {
+	token, err := g.token(context.Background())
 	if err != nil {
-		return errors.Wrap(err, "cred http request")
+		return "", errors.Wrap(err, "get access token")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return errors.Errorf("response status: %s, body: %s", resp.Status, string(body))
+	return token.AccessToken, nil
+}
+
+// token returns the store's cached token if it is still valid past g.refreshLeeway, otherwise it fetches a fresh one and saves it to the store. Concurrent callers that all observe a stale token coalesce onto a single HTTP round-trip, keyed on the grant's client ID.
+func (g *grantRequest) token(ctx context.Context) (*oauth2.Token, error) {
+	if token, err := g.store.Load(ctx); err == nil && g.validWithLeeway(token) {
+		return token, nil
 	}
 
-	var grant clientCredsGrant
-	if err := json.NewDecoder(resp.Body).Decode(&grant); err != nil {
-		return errors.Wrap(err, "decode grant from response")
+	result, err, _ := g.sf.Do(g.clientID, func() (interface{}, error) {
+		if token, err := g.store.Load(ctx); err == nil && g.validWithLeeway(token) {
+			return token, nil
+		}
+
+		token, err := g.fetcher.fetch(ctx, g.retryingHTTPClient())
+		if err != nil {
+			return nil, toAuthError(err)
+		}
+
+		alignExpiryToClaims(token)
+
+		if err := g.store.Save(ctx, token); err != nil {
+			return nil, errors.Wrap(err, "save token")
+		}
+
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	grant.ExpiresIn *= time.Second
+	return result.(*oauth2.Token), nil
+}
 
-	g.issuedAt = g.nowFn()
-	g.grant = &grant
+func (g *grantRequest) validWithLeeway(token *oauth2.Token) bool {
+	if token == nil {
+		return false
+	}
 
-	return nil
+	return token.Expiry.IsZero() || g.clock.Now().Add(g.refreshLeeway).Before(token.Expiry)
 }
 
-func (g *Grant) needsRenew() bool {
-	if g.grant == nil {
-		return true
+// retryingHTTPClient returns g.httpClient with its Transport wrapped to apply g.retryPolicy.
+func (g *grantRequest) retryingHTTPClient() *http.Client {
+	base := g.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
 	}
 
-	expires := g.issuedAt.Add(g.grant.ExpiresIn)
-	return g.nowFn().After(expires)
+	client := *g.httpClient
+	client.Transport = &retryTransport{base: base, policy: g.retryPolicy, clock: g.clock}
+
+	return &client
+}
+
+type tokenSourceFunc func() (*oauth2.Token, error)
+
+func (f tokenSourceFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
+
+func (g *grantRequest) TokenSource(ctx context.Context) oauth2.TokenSource {
+	return tokenSourceFunc(func() (*oauth2.Token, error) {
+		return g.token(ctx)
+	})
+}
+
+func (g *grantRequest) Client(ctx context.Context) *http.Client {
+	return oauth2.NewClient(ctx, g.TokenSource(ctx))
+}
+
+func (g *grantRequest) StartAutoRefresh(ctx context.Context) {
+	go g.autoRefresh(ctx)
+}
+
+func (g *grantRequest) autoRefresh(ctx context.Context) {
+	for {
+		token, err := g.token(ctx)
+
+		wait := g.refreshLeeway
+		if err == nil && !token.Expiry.IsZero() {
+			if untilRefresh := time.Until(token.Expiry) - g.refreshLeeway; untilRefresh > 0 {
+				wait = untilRefresh
+			}
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+	}
 }
@@ -1,46 +1,54 @@
 package auth0grant
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"github.com/google/go-cmp/cmp"
+	jose "github.com/go-jose/go-jose/v4"
+	"golang.org/x/oauth2"
 )
 
-var (
-	expectedGrant = clientCredsGrant{
-		AccessToken: "open-sesame",
-		Scope:       "u-cant-touch-dis",
-		TokenType:   "Bearer",
-		ExpiresIn:   time.Second,
-	}
+// instantClock behaves like realClock for Now, but never actually sleeps, so retry-backoff tests run fast and deterministically.
+type instantClock struct{}
 
-	testCredRequest = CredentialsRequest{
-		ClientID:     "joe-blow-id",
-		ClientSecret: "joe-blow-secret",
-		Audience:     "https://api.blowcorp.co/",
-		GrantType:    CLIENT_CREDS_GRANT_TYPE,
-	}
-)
+func (instantClock) Now() time.Time      { return time.Now() }
+func (instantClock) Sleep(time.Duration) {}
+
+var testCredRequest = CredentialsRequest{
+	ClientID:     "joe-blow-id",
+	ClientSecret: "joe-blow-secret",
+	Audience:     "https://api.blowcorp.co/",
+	GrantType:    CLIENT_CREDS_GRANT_TYPE,
+}
 
 const (
-	testGrantResponse = `{"access_token":"open-sesame","scope":"u-cant-touch-dis","expires_in":1,"token_type":"Bearer"}`
-	tokenPath         = "/oauth/token"
+	tokenPath       = "/oauth/token"
+	testAccessToken = "open-sesame"
 )
 
 type testGrantServer struct {
-	server *httptest.Server
-	ncalls int
-	t      *testing.T
+	server    *httptest.Server
+	ncalls    int
+	expiresIn int
+	t         *testing.T
 }
 
-func newTestGrantServer(t *testing.T, ncalls int) *testGrantServer {
+func newTestGrantServer(t *testing.T, ncalls, expiresIn int) *testGrantServer {
 	t.Helper()
 
-	s := &testGrantServer{t: t, ncalls: ncalls}
+	s := &testGrantServer{t: t, ncalls: ncalls, expiresIn: expiresIn}
 	s.server = httptest.NewServer(http.HandlerFunc(s.handler))
 	return s
 }
@@ -68,112 +76,579 @@ func (s *testGrantServer) handler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		s.t.Fatalf("http method got: %s expected: %s", r.Method, "POST")
 	}
+
 	mimeType := r.Header.Get("Content-Type")
-	if mimeType != "application/json" {
-		s.t.Fatalf("mime type got: %s expected: %s", mimeType, "application/json")
+	if mimeType != "application/x-www-form-urlencoded" {
+		s.t.Fatalf("mime type got: %s expected: %s", mimeType, "application/x-www-form-urlencoded")
 	}
 
-	var request CredentialsRequest
+	if err := r.ParseForm(); err != nil {
+		s.t.Fatal("parse form body:", err)
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.t.Fatal("json decode body:", err)
+	if got := r.PostForm.Get("grant_type"); got != CLIENT_CREDS_GRANT_TYPE {
+		s.t.Fatalf("grant_type got: %s expected: %s", got, CLIENT_CREDS_GRANT_TYPE)
 	}
-	if err := r.Body.Close(); err != nil {
-		s.t.Fatal("close request body:", err)
+	if got := r.PostForm.Get("client_id"); got != testCredRequest.ClientID {
+		s.t.Fatalf("client_id got: %s expected: %s", got, testCredRequest.ClientID)
 	}
-	if !cmp.Equal(request, testCredRequest) {
-		s.t.Fatalf("cred request expected: %+v got: %+v", request, testCredRequest)
+	if got := r.PostForm.Get("client_secret"); got != testCredRequest.ClientSecret {
+		s.t.Fatalf("client_secret got: %s expected: %s", got, testCredRequest.ClientSecret)
 	}
-
-	if _, err := w.Write([]byte(testGrantResponse)); err != nil {
-		s.t.Fatal("write grant response:", err)
+	if got := r.PostForm.Get("audience"); got != testCredRequest.Audience {
+		s.t.Fatalf("audience got: %s expected: %s", got, testCredRequest.Audience)
 	}
 
 	s.ncalls--
-}
-
-func testNewGrant(tokenURL string, credRequest *CredentialsRequest, nowFn func() time.Time) Grant {
-	grant := NewGrant(tokenURL, &testCredRequest)
-	grant.(*grantRequest).nowFn = nowFn
-	return grant
-}
 
-func testNowFn(offset int64) func() time.Time {
-	return func() time.Time {
-		return time.Unix(offset, 0)
-	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"access_token":"%s","scope":"u-cant-touch-dis","expires_in":%d,"token_type":"Bearer"}`, testAccessToken, s.expiresIn)
 }
 
 func TestGrantAPI(t *testing.T) {
 	t.Run("Retrieves the access token for the first time", func(t *testing.T) {
-		server := newTestGrantServer(t, 1)
+		server := newTestGrantServer(t, 1, 3600)
 		defer server.Close()
 
-		grant := testNewGrant(server.TokenURL(), &testCredRequest, testNowFn(1))
+		grant := NewGrant(server.TokenURL(), &testCredRequest)
 
 		token, err := grant.GetAccessToken()
 		if err != nil {
 			t.Fatal("get access token:", err)
 		}
-		if token != expectedGrant.AccessToken {
-			t.Fatalf("wrong access token got: %s expected: %s", token, expectedGrant.AccessToken)
+		if token != testAccessToken {
+			t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
 		}
+	})
 
-		req, ok := grant.(*grantRequest)
-		if !ok {
-			t.Fatal("cast to *grantRequest")
+	t.Run("Retrieves the cached access token if it isn't expired yet", func(t *testing.T) {
+		server := newTestGrantServer(t, 1, 3600)
+		defer server.Close()
+
+		grant := NewGrant(server.TokenURL(), &testCredRequest)
+
+		if _, err := grant.GetAccessToken(); err != nil {
+			t.Fatal("get access token:", err)
 		}
-		if !cmp.Equal(*req.grant, expectedGrant) {
-			t.Fatalf("wrong grant got: %+v expected: %+v", *req.grant, expectedGrant)
+
+		token, err := grant.GetAccessToken()
+		if err != nil {
+			t.Fatal("get access token:", err)
+		}
+		if token != testAccessToken {
+			t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
 		}
 	})
 
-	t.Run("Renews and retrieves an access token when the previous one has expired", func(t *testing.T) {
-		server := newTestGrantServer(t, 1)
+	t.Run("Renews the access token once the previous one has expired", func(t *testing.T) {
+		server := newTestGrantServer(t, 2, 1)
 		defer server.Close()
 
-		grant := testNewGrant(server.TokenURL(), &testCredRequest, testNowFn(3))
-		req, ok := grant.(*grantRequest)
-		if !ok {
-			t.Fatal("cast to *grantRequest")
+		grant := NewGrant(server.TokenURL(), &testCredRequest)
+
+		if _, err := grant.GetAccessToken(); err != nil {
+			t.Fatal("get access token:", err)
 		}
 
-		req.grant = &expectedGrant
-		req.issuedAt = time.Unix(1, 0)
+		time.Sleep(1100 * time.Millisecond)
 
 		token, err := grant.GetAccessToken()
 		if err != nil {
 			t.Fatal("get access token:", err)
 		}
-		if token != expectedGrant.AccessToken {
-			t.Fatalf("wrong access token got: %s expected: %s", token, expectedGrant.AccessToken)
+		if token != testAccessToken {
+			t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
 		}
+	})
+
+	t.Run("Renews ahead of expiry once within the refresh leeway", func(t *testing.T) {
+		server := newTestGrantServer(t, 2, 2)
+		defer server.Close()
 
-		expectedTime := time.Unix(3, 0)
-		if !req.issuedAt.Equal(expectedTime) {
-			t.Fatalf("wrong issue time got: %s, expected: %s", req.issuedAt.Format(time.RFC3339), expectedTime.Format(time.RFC3339))
+		grant := NewGrant(server.TokenURL(), &testCredRequest, WithRefreshLeeway(3*time.Second))
+
+		if _, err := grant.GetAccessToken(); err != nil {
+			t.Fatal("get access token:", err)
+		}
+		if _, err := grant.GetAccessToken(); err != nil {
+			t.Fatal("get access token:", err)
 		}
 	})
 
-	t.Run("Retrieves the cached access token if it isn't expired yet", func(t *testing.T) {
-		server := newTestGrantServer(t, 0) // <-- server shouldn't get called!
+	t.Run("Coalesces concurrent renewals into a single request", func(t *testing.T) {
+		server := newTestGrantServer(t, 1, 3600)
+		defer server.Close()
+
+		grant := NewGrant(server.TokenURL(), &testCredRequest)
+
+		const callers = 10
+		var wg sync.WaitGroup
+		tokens := make([]string, callers)
+		errs := make([]error, callers)
+
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tokens[i], errs[i] = grant.GetAccessToken()
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < callers; i++ {
+			if errs[i] != nil {
+				t.Fatal("get access token:", errs[i])
+			}
+			if tokens[i] != testAccessToken {
+				t.Fatalf("wrong access token got: %s expected: %s", tokens[i], testAccessToken)
+			}
+		}
+	})
+
+	t.Run("Reuses a token saved in a FileTokenStore across Grant instances", func(t *testing.T) {
+		server := newTestGrantServer(t, 1, 3600)
 		defer server.Close()
 
-		grant := testNewGrant(server.TokenURL(), &testCredRequest, testNowFn(1))
-		req, ok := grant.(*grantRequest)
-		if !ok {
-			t.Fatal("cast to *grantRequest")
+		store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+
+		first := NewGrant(server.TokenURL(), &testCredRequest, WithTokenStore(store))
+		if _, err := first.GetAccessToken(); err != nil {
+			t.Fatal("get access token:", err)
 		}
 
-		req.grant = &expectedGrant
-		req.issuedAt = time.Unix(1, 0)
+		second := NewGrant(server.TokenURL(), &testCredRequest, WithTokenStore(store))
+
+		token, err := second.GetAccessToken()
+		if err != nil {
+			t.Fatal("get access token:", err)
+		}
+		if token != testAccessToken {
+			t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
+		}
+	})
+
+	t.Run("Reuses a token saved in a KeyringTokenStore across Grant instances", func(t *testing.T) {
+		server := newTestGrantServer(t, 1, 3600)
+		defer server.Close()
+
+		store := NewKeyringTokenStore(newFakeKeyring(), "go-auth0-grant-test", "joe-blow-id")
+
+		first := NewGrant(server.TokenURL(), &testCredRequest, WithTokenStore(store))
+		if _, err := first.GetAccessToken(); err != nil {
+			t.Fatal("get access token:", err)
+		}
+
+		second := NewGrant(server.TokenURL(), &testCredRequest, WithTokenStore(store))
+
+		token, err := second.GetAccessToken()
+		if err != nil {
+			t.Fatal("get access token:", err)
+		}
+		if token != testAccessToken {
+			t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
+		}
+	})
+
+	t.Run("Retries a 5xx response and succeeds once the server recovers", func(t *testing.T) {
+		var calls int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"%s","scope":"u-cant-touch-dis","expires_in":3600,"token_type":"Bearer"}`, testAccessToken)
+		}))
+		defer server.Close()
+
+		grant := NewGrant(server.URL+tokenPath, &testCredRequest, WithClock(instantClock{}))
 
 		token, err := grant.GetAccessToken()
 		if err != nil {
 			t.Fatal("get access token:", err)
 		}
-		if token != expectedGrant.AccessToken {
-			t.Fatalf("wrong access token got: %s expected: %s", token, expectedGrant.AccessToken)
+		if token != testAccessToken {
+			t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
+		}
+		if calls != 3 {
+			t.Fatalf("wrong # calls got: %d expected: %d", calls, 3)
+		}
+	})
+
+	t.Run("Gives up immediately on a 4xx response with a typed AuthError", func(t *testing.T) {
+		var calls int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"client is not authorized"}`)
+		}))
+		defer server.Close()
+
+		grant := NewGrant(server.URL+tokenPath, &testCredRequest, WithClock(instantClock{}))
+
+		if _, err := grant.GetAccessToken(); err == nil {
+			t.Fatal("expected an error")
+		} else {
+			var authErr *AuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("expected an *AuthError, got: %T: %v", err, err)
+			}
+			if authErr.Code != "invalid_client" {
+				t.Fatalf("wrong auth error code got: %s expected: %s", authErr.Code, "invalid_client")
+			}
+		}
+		if calls != 1 {
+			t.Fatalf("wrong # calls got: %d expected: %d", calls, 1)
+		}
+	})
+
+	t.Run("Sends client credentials in the POST body by default", func(t *testing.T) {
+		server := newTestGrantServer(t, 1, 3600)
+		defer server.Close()
+
+		grant := NewGrant(server.TokenURL(), &testCredRequest)
+
+		if _, err := grant.GetAccessToken(); err != nil {
+			t.Fatal("get access token:", err)
+		}
+	})
+
+	t.Run("Sends client credentials via HTTP Basic auth when WithAuthStyle(AuthStyleInHeader) is set", func(t *testing.T) {
+		var calls int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+
+			clientID, clientSecret, ok := r.BasicAuth()
+			if !ok {
+				t.Fatal("expected client credentials in the Authorization header")
+			}
+			if clientID != testCredRequest.ClientID {
+				t.Fatalf("client_id got: %s expected: %s", clientID, testCredRequest.ClientID)
+			}
+			if clientSecret != testCredRequest.ClientSecret {
+				t.Fatalf("client_secret got: %s expected: %s", clientSecret, testCredRequest.ClientSecret)
+			}
+
+			if err := r.ParseForm(); err != nil {
+				t.Fatal("parse form body:", err)
+			}
+			if got := r.PostForm.Get("client_id"); got != "" {
+				t.Fatalf("client_id got in form body: %s expected it to be carried in the Authorization header instead", got)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"%s","scope":"u-cant-touch-dis","expires_in":3600,"token_type":"Bearer"}`, testAccessToken)
+		}))
+		defer server.Close()
+
+		grant := NewGrant(server.URL+tokenPath, &testCredRequest, WithAuthStyle(oauth2.AuthStyleInHeader))
+
+		token, err := grant.GetAccessToken()
+		if err != nil {
+			t.Fatal("get access token:", err)
+		}
+		if token != testAccessToken {
+			t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
+		}
+		if calls != 1 {
+			t.Fatalf("wrong # calls got: %d expected: %d", calls, 1)
+		}
+	})
+
+	t.Run("StartAutoRefresh renews the token ahead of expiry and stops once ctx is canceled", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"%s","scope":"u-cant-touch-dis","expires_in":5,"token_type":"Bearer"}`, testAccessToken)
+		}))
+		defer server.Close()
+
+		grant := NewGrant(server.URL+tokenPath, &testCredRequest, WithRefreshLeeway(4700*time.Millisecond))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		grant.StartAutoRefresh(ctx)
+
+		time.Sleep(450 * time.Millisecond)
+		if got := atomic.LoadInt32(&calls); got < 2 {
+			t.Fatalf("wrong # calls got: %d expected at least: %d (should have renewed ahead of the 5s expiry)", got, 2)
+		}
+
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		stopped := atomic.LoadInt32(&calls)
+
+		time.Sleep(400 * time.Millisecond)
+		if got := atomic.LoadInt32(&calls); got != stopped {
+			t.Fatalf("wrong # calls got: %d expected: %d (StartAutoRefresh kept renewing after ctx was canceled)", got, stopped)
+		}
+	})
+}
+
+func TestNewGrantWithPrivateKeyJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("generate rsa key:", err)
+	}
+
+	const (
+		testClientID = "joe-blow-id"
+		testAudience = "https://api.blowcorp.co/"
+		testKid      = "test-key-1"
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal("parse form body:", err)
+		}
+
+		if got := r.PostForm.Get("client_assertion_type"); got != clientAssertionTypeJWTBearer {
+			t.Fatalf("client_assertion_type got: %s expected: %s", got, clientAssertionTypeJWTBearer)
+		}
+
+		assertion := r.PostForm.Get("client_assertion")
+		parts := strings.Split(assertion, ".")
+		if len(parts) != 3 {
+			t.Fatalf("client_assertion is not a JWT: %s", assertion)
+		}
+
+		if got := r.PostForm.Get("grant_type"); got != CLIENT_CREDS_GRANT_TYPE {
+			t.Fatalf("grant_type got: %s expected: %s", got, CLIENT_CREDS_GRANT_TYPE)
+		}
+		if got := r.PostForm.Get("client_id"); got != testClientID {
+			t.Fatalf("client_id got: %s expected: %s", got, testClientID)
+		}
+		if got := r.PostForm.Get("audience"); got != testAudience {
+			t.Fatalf("audience got: %s expected: %s", got, testAudience)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"%s","scope":"u-cant-touch-dis","expires_in":3600,"token_type":"Bearer"}`, testAccessToken)
+	}))
+	defer server.Close()
+
+	grant, err := NewGrantWithPrivateKeyJWT(server.URL+tokenPath, testClientID, testAudience, key, testKid)
+	if err != nil {
+		t.Fatal("new grant:", err)
+	}
+
+	token, err := grant.GetAccessToken()
+	if err != nil {
+		t.Fatal("get access token:", err)
+	}
+	if token != testAccessToken {
+		t.Fatalf("wrong access token got: %s expected: %s", token, testAccessToken)
+	}
+}
+
+func TestClaims(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("generate rsa key:", err)
+	}
+
+	const (
+		testClientID = "joe-blow-id"
+		testAudience = "https://api.blowcorp.co/"
+		testKid      = "test-key-1"
+	)
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithHeader("kid", testKid),
+	)
+	if err != nil {
+		t.Fatal("new jwt signer:", err)
+	}
+
+	var issuer string
+
+	signAccessToken := func(t *testing.T) string {
+		t.Helper()
+
+		claims, err := json.Marshal(map[string]interface{}{
+			"iss":         issuer,
+			"sub":         testClientID,
+			"aud":         testAudience,
+			"scope":       "read:things",
+			"permissions": []string{"read:things"},
+			"gty":         "client-credentials",
+			"azp":         testClientID,
+			"iat":         time.Now().Unix(),
+			"exp":         time.Now().Add(time.Hour).Unix(),
+		})
+		if err != nil {
+			t.Fatal("encode claims:", err)
+		}
+
+		jws, err := signer.Sign(claims)
+		if err != nil {
+			t.Fatal("sign jwt:", err)
+		}
+
+		compact, err := jws.CompactSerialize()
+		if err != nil {
+			t.Fatal("serialize jwt:", err)
+		}
+
+		return compact
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(tokenPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"%s","scope":"read:things","expires_in":3600,"token_type":"Bearer"}`, signAccessToken(t))
+	})
+	mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: testKid, Algorithm: "RS256", Use: "sig"},
+		}}
+		if err := json.NewEncoder(w).Encode(jwks); err != nil {
+			t.Fatal("encode jwks:", err)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	issuer = server.URL + "/"
+
+	grant := NewGrant(server.URL+tokenPath, &CredentialsRequest{
+		ClientID:     testClientID,
+		ClientSecret: "joe-blow-secret",
+		Audience:     testAudience,
+		GrantType:    CLIENT_CREDS_GRANT_TYPE,
+	})
+
+	t.Run("Claims returns the unverified claim set", func(t *testing.T) {
+		claims, err := grant.Claims()
+		if err != nil {
+			t.Fatal("claims:", err)
+		}
+		if claims["sub"] != testClientID {
+			t.Fatalf("sub got: %v expected: %s", claims["sub"], testClientID)
+		}
+	})
+
+	t.Run("VerifiedClaims verifies the signature and populates typed accessors", func(t *testing.T) {
+		jwks, err := NewJWKSCache(server.URL + "/.well-known/jwks.json").Get(context.Background())
+		if err != nil {
+			t.Fatal("get jwks:", err)
+		}
+
+		claims, err := grant.VerifiedClaims(jwks)
+		if err != nil {
+			t.Fatal("verified claims:", err)
+		}
+		if claims.Scope != "read:things" {
+			t.Fatalf("scope got: %s expected: %s", claims.Scope, "read:things")
+		}
+		if claims.Gty != "client-credentials" {
+			t.Fatalf("gty got: %s expected: %s", claims.Gty, "client-credentials")
+		}
+		if !containsString(claims.Audience, testAudience) {
+			t.Fatalf("aud got: %v expected to contain: %s", claims.Audience, testAudience)
+		}
+	})
+}
+
+// fakeKeyring is an in-memory Keyring for tests. Set getErr to make every Get fail, regardless of what's stored, to exercise KeyringTokenStore's "any Get failure is a cache miss" contract.
+type fakeKeyring struct {
+	mu     sync.Mutex
+	values map[string]string
+	getErr error
+}
+
+func newFakeKeyring() *fakeKeyring {
+	return &fakeKeyring{values: map[string]string{}}
+}
+
+func (k *fakeKeyring) entryKey(service, user string) string {
+	return service + "/" + user
+}
+
+func (k *fakeKeyring) Set(service, user, password string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.values[k.entryKey(service, user)] = password
+	return nil
+}
+
+func (k *fakeKeyring) Get(service, user string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.getErr != nil {
+		return "", k.getErr
+	}
+
+	password, ok := k.values[k.entryKey(service, user)]
+	if !ok {
+		return "", errors.New("fakeKeyring: secret not found")
+	}
+
+	return password, nil
+}
+
+func (k *fakeKeyring) Delete(service, user string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.values, k.entryKey(service, user))
+	return nil
+}
+
+func TestKeyringTokenStore(t *testing.T) {
+	t.Run("Round-trips a token through Save, Load, and Delete", func(t *testing.T) {
+		store := NewKeyringTokenStore(newFakeKeyring(), "go-auth0-grant-test", "joe-blow-id")
+		ctx := context.Background()
+
+		token := &Token{AccessToken: testAccessToken, TokenType: "Bearer"}
+		if err := store.Save(ctx, token); err != nil {
+			t.Fatal("save token:", err)
+		}
+
+		loaded, err := store.Load(ctx)
+		if err != nil {
+			t.Fatal("load token:", err)
+		}
+		if loaded.AccessToken != testAccessToken {
+			t.Fatalf("access token got: %s expected: %s", loaded.AccessToken, testAccessToken)
+		}
+
+		if err := store.Delete(ctx); err != nil {
+			t.Fatal("delete token:", err)
+		}
+
+		loaded, err = store.Load(ctx)
+		if err != nil {
+			t.Fatal("load token after delete:", err)
+		}
+		if loaded != nil {
+			t.Fatalf("expected a nil token after delete, got: %+v", loaded)
+		}
+	})
+
+	t.Run("Treats a broken Keyring backend as a cache miss rather than an error", func(t *testing.T) {
+		keyring := newFakeKeyring()
+		store := NewKeyringTokenStore(keyring, "go-auth0-grant-test", "joe-blow-id")
+		ctx := context.Background()
+
+		if err := store.Save(ctx, &Token{AccessToken: testAccessToken}); err != nil {
+			t.Fatal("save token:", err)
+		}
+
+		keyring.getErr = errors.New("keyring backend unavailable")
+
+		token, err := store.Load(ctx)
+		if err != nil {
+			t.Fatalf("expected Load to swallow the Keyring error as a cache miss, got: %v", err)
+		}
+		if token != nil {
+			t.Fatalf("expected a nil token, got: %+v", token)
 		}
 	})
 }
@@ -0,0 +1,216 @@
+package auth0grant
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// clientAssertionTypeJWTBearer is the client_assertion_type Auth0 expects for private_key_jwt (RFC 7523) client authentication.
+const clientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// clientAssertionLifetime is how long each signed assertion is valid for. A fresh one is built on every renewal, so this only needs to outlive a single token request.
+const clientAssertionLifetime = 60 * time.Second
+
+// NewGrantWithPrivateKeyJWT obtains credentials from Auth0 using private_key_jwt (RFC 7523) client authentication instead of a shared client secret. On every renewal it builds and signs a short-lived JWT assertion with signer and presents it as client_assertion alongside client_assertion_type=urn:ietf:params:oauth:client-assertion-type:jwt-bearer. kid must match the key ID configured on the Auth0 M2M application's "Private Key JWT" credential, and signer's public key must be RSA (signed RS256) or P-256 ECDSA (signed ES256).
+func NewGrantWithPrivateKeyJWT(tokenURL, clientID, audience string, signer crypto.Signer, kid string, opts ...GrantOption) (Grant, error) {
+	fetcher, err := newPrivateKeyJWTFetcher(tokenURL, clientID, audience, signer, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGrant(fetcher, tokenURL, clientID, audience, opts), nil
+}
+
+// privateKeyJWTFetcher authenticates token requests per RFC 7523: instead of a client_secret, it signs a short-lived JWT assertion with signer on every renewal.
+type privateKeyJWTFetcher struct {
+	tokenURL string
+	clientID string
+	audience string
+	signer   crypto.Signer
+	kid      string
+	alg      string
+}
+
+func newPrivateKeyJWTFetcher(tokenURL, clientID, audience string, signer crypto.Signer, kid string) (*privateKeyJWTFetcher, error) {
+	alg, err := jwtAlgForKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &privateKeyJWTFetcher{
+		tokenURL: tokenURL,
+		clientID: clientID,
+		audience: audience,
+		signer:   signer,
+		kid:      kid,
+		alg:      alg,
+	}, nil
+}
+
+func (f *privateKeyJWTFetcher) fetch(ctx context.Context, httpClient *http.Client) (*oauth2.Token, error) {
+	assertion, err := f.signAssertion()
+	if err != nil {
+		return nil, errors.Wrap(err, "sign client assertion")
+	}
+
+	form := url.Values{
+		"grant_type":            {CLIENT_CREDS_GRANT_TYPE},
+		"client_id":             {f.clientID},
+		"audience":              {f.audience},
+		"client_assertion_type": {clientAssertionTypeJWTBearer},
+		"client_assertion":      {assertion},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "build token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "token request")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read token response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, authErrorFromResponse(resp.StatusCode, body)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		Scope       string `json:"scope"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.Wrap(err, "decode token response")
+	}
+
+	token := &oauth2.Token{
+		AccessToken: payload.AccessToken,
+		TokenType:   payload.TokenType,
+		Expiry:      time.Now().Add(time.Duration(payload.ExpiresIn) * time.Second),
+	}
+
+	return token.WithExtra(map[string]interface{}{"scope": payload.Scope}), nil
+}
+
+// signAssertion builds and signs the JWT bearer assertion described in RFC 7523 section 3.
+func (f *privateKeyJWTFetcher) signAssertion() (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": f.alg,
+		"typ": "JWT",
+		"kid": f.kid,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "encode jwt header")
+	}
+
+	jti, err := randomJTI()
+	if err != nil {
+		return "", errors.Wrap(err, "generate jti")
+	}
+
+	now := time.Now()
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": f.clientID,
+		"sub": f.clientID,
+		"aud": f.tokenURL,
+		"jti": jti,
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "encode jwt claims")
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	signature, err := f.sign([]byte(signingInput))
+	if err != nil {
+		return "", errors.Wrap(err, "sign jwt")
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (f *privateKeyJWTFetcher) sign(signingInput []byte) ([]byte, error) {
+	digest := sha256.Sum256(signingInput)
+
+	signature, err := f.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.alg == "ES256" {
+		return ecdsaRawSignature(signature)
+	}
+
+	return signature, nil
+}
+
+// jwtAlgForKey picks the JWS alg matching signer's public key: RS256 for RSA, ES256 for P-256 ECDSA. Other key types aren't supported by Auth0's private_key_jwt credential.
+func jwtAlgForKey(pub crypto.PublicKey) (string, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		if key.Curve == elliptic.P256() {
+			return "ES256", nil
+		}
+		return "", errors.Errorf("unsupported EC curve %q for private_key_jwt", key.Curve.Params().Name)
+	default:
+		return "", errors.Errorf("unsupported signer public key type %T for private_key_jwt", pub)
+	}
+}
+
+// ecdsaRawSignature converts the ASN.1 DER signature crypto.Signer.Sign returns for an ECDSA key into the raw fixed-width r||s encoding JWS ES256 requires.
+func ecdsaRawSignature(der []byte) ([]byte, error) {
+	var parsed struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &parsed); err != nil {
+		return nil, errors.Wrap(err, "decode ecdsa signature")
+	}
+
+	const componentSize = 32 // P-256 field element size in bytes
+
+	raw := make([]byte, 2*componentSize)
+	parsed.R.FillBytes(raw[:componentSize])
+	parsed.S.FillBytes(raw[componentSize:])
+
+	return raw, nil
+}
+
+func randomJTI() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,207 @@
+package auth0grant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// AuthError is returned when Auth0 rejects a token request. Callers can errors.As into this type to branch on Code (e.g. "invalid_client" or "invalid_grant" means the credentials themselves are wrong, and retrying won't help) rather than string-matching the underlying error.
+type AuthError struct {
+	// Code is Auth0's machine-readable error, e.g. "invalid_client", "invalid_grant", "unauthorized_client".
+	Code string
+
+	// Description is Auth0's human-readable explanation of Code.
+	Description string
+
+	// StatusCode is the HTTP status Auth0 responded with.
+	StatusCode int
+
+	// Body is the raw response body, for the cases where it didn't parse as Auth0's usual {"error": ..., "error_description": ...} envelope.
+	Body []byte
+}
+
+func (e *AuthError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("auth0: %s: %s (status %d)", e.Code, e.Description, e.StatusCode)
+	}
+
+	return fmt.Sprintf("auth0: unexpected response status %d: %s", e.StatusCode, e.Body)
+}
+
+// toAuthError converts the *oauth2.RetrieveError that clientcredentials.Config.Token returns on a non-2xx response into our own *AuthError, so callers don't need to depend on golang.org/x/oauth2 just to inspect Auth0's error envelope.
+func toAuthError(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return err
+	}
+
+	authErr := &AuthError{
+		Code:        retrieveErr.ErrorCode,
+		Description: retrieveErr.ErrorDescription,
+		Body:        retrieveErr.Body,
+	}
+	if retrieveErr.Response != nil {
+		authErr.StatusCode = retrieveErr.Response.StatusCode
+	}
+
+	return authErr
+}
+
+// authErrorFromResponse builds an *AuthError from a non-200 response that was obtained outside clientcredentials.Config (e.g. by the private_key_jwt fetcher, which posts the token request itself). It best-effort parses Auth0's {"error": ..., "error_description": ...} envelope out of body.
+func authErrorFromResponse(statusCode int, body []byte) *AuthError {
+	var envelope struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &envelope)
+
+	return &AuthError{
+		Code:        envelope.Error,
+		Description: envelope.ErrorDescription,
+		StatusCode:  statusCode,
+		Body:        body,
+	}
+}
+
+// RetryPolicy controls how a Grant retries a failed token request against Auth0.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made, including the first. A value <= 1 disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the first retry. It doubles after each subsequent retry, capped at MaxDelay, and jittered by up to 50%.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff between attempts.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used when NewGrant isn't given a WithRetryPolicy option.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// WithRetryPolicy overrides DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) GrantOption {
+	return func(g *grantRequest) {
+		g.retryPolicy = policy
+	}
+}
+
+// WithHTTPClient overrides the *http.Client used to request tokens from Auth0. Its Transport is wrapped to apply the Grant's RetryPolicy; pass a client with a custom net/http.Transport to control proxying, TLS, or connection timeouts.
+func WithHTTPClient(client *http.Client) GrantOption {
+	return func(g *grantRequest) {
+		g.httpClient = client
+	}
+}
+
+// Clock abstracts wall-clock time for refresh-leeway checks and retry backoff, so tests don't have to wait on a real clock.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// WithClock overrides the Clock a Grant uses instead of the real one. This exists for tests; production callers shouldn't need it.
+func WithClock(clock Clock) GrantOption {
+	return func(g *grantRequest) {
+		g.clock = clock
+	}
+}
+
+// retryTransport wraps an http.RoundTripper with RetryPolicy: it retries on network errors and 5xx responses with exponential backoff and jitter, honoring a Retry-After header when Auth0 sends one, and gives up immediately on a 4xx response since retrying invalid_client, invalid_grant, etc. can't succeed.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	clock  Clock
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, errors.Wrap(err, "buffer request body for retry")
+		}
+		req.Body.Close()
+	}
+
+	attempts := t.policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var resp *http.Response
+	var err error
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		if body != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err = t.base.RoundTrip(req)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+
+		if attempt == attempts-1 {
+			break
+		}
+
+		delay := t.retryDelay(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		t.clock.Sleep(delay)
+	}
+
+	return resp, err
+}
+
+func (t *retryTransport) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfterDelay(resp.Header.Get("Retry-After"), t.clock); ok {
+			return d
+		}
+	}
+
+	delay := t.policy.BaseDelay << attempt
+	if delay <= 0 || delay > t.policy.MaxDelay {
+		delay = t.policy.MaxDelay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+func retryAfterDelay(header string, clock Clock) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if when, err := http.ParseTime(header); err == nil {
+		if d := when.Sub(clock.Now()); d > 0 {
+			return d, true
+		}
+	}
+
+	return 0, false
+}
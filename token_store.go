@@ -0,0 +1,156 @@
+package auth0grant
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// Token is the cached form of a grant. It is an alias for oauth2.Token so that TokenStore implementations don't need to import golang.org/x/oauth2 themselves.
+type Token = oauth2.Token
+
+// TokenStore is consulted by a Grant before it requests a new token from Auth0, and is updated every time a fresh token is issued. Implementing this lets a Grant reuse a token across process restarts (or across processes entirely), which matters for short-lived CLIs and Lambda-style functions: Auth0 M2M tokens are metered per issuance, so skipping a redundant renewal on every cold start is a real cost saving.
+//
+// Load should return a nil token and a nil error if nothing is stored yet; Grant treats that the same as a cache miss.
+type TokenStore interface {
+	Load(ctx context.Context) (*Token, error)
+	Save(ctx context.Context, token *Token) error
+	Delete(ctx context.Context) error
+}
+
+// WithTokenStore overrides the default in-memory TokenStore with store. Use this to share a token across process restarts (FileTokenStore) or across processes (a KeyringTokenStore backed by Vault, Redis, or the OS keychain).
+func WithTokenStore(store TokenStore) GrantOption {
+	return func(g *grantRequest) {
+		g.store = store
+	}
+}
+
+// memoryTokenStore is the default TokenStore: it keeps the token cached in the Grant's own process memory, same as before TokenStore existed.
+type memoryTokenStore struct {
+	token atomic.Pointer[Token]
+}
+
+func (s *memoryTokenStore) Load(ctx context.Context) (*Token, error) {
+	return s.token.Load(), nil
+}
+
+func (s *memoryTokenStore) Save(ctx context.Context, token *Token) error {
+	s.token.Store(token)
+	return nil
+}
+
+func (s *memoryTokenStore) Delete(ctx context.Context) error {
+	s.token.Store(nil)
+	return nil
+}
+
+// FileTokenStore persists a token as JSON in a single file with 0600 permissions, so a short-lived CLI or similar single-user process can reuse a token across invocations instead of requesting a new one every time it starts up.
+type FileTokenStore struct {
+	// Path is the file the token is read from and written to.
+	Path string
+}
+
+// NewFileTokenStore returns a FileTokenStore that reads and writes the token as JSON at path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+func (s *FileTokenStore) Load(ctx context.Context) (*Token, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read token file")
+	}
+
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, errors.Wrap(err, "decode token file")
+	}
+
+	return &token, nil
+}
+
+func (s *FileTokenStore) Save(ctx context.Context, token *Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return errors.Wrap(err, "encode token")
+	}
+
+	if err := ioutil.WriteFile(s.Path, data, 0600); err != nil {
+		return errors.Wrap(err, "write token file")
+	}
+
+	return nil
+}
+
+func (s *FileTokenStore) Delete(ctx context.Context) error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove token file")
+	}
+
+	return nil
+}
+
+// Keyring is the minimal interface satisfied by OS keychain, Vault, and Redis client wrappers alike (it matches github.com/zalando/go-keyring, among others). KeyringTokenStore adapts one of these into a TokenStore.
+type Keyring interface {
+	Set(service, user, password string) error
+	Get(service, user string) (string, error)
+	Delete(service, user string) error
+}
+
+// KeyringTokenStore persists a token through a Keyring, addressed by Service and User. This is how a Grant plugs into Vault, Redis, or an OS keychain: wrap whichever client library you use behind Keyring.
+type KeyringTokenStore struct {
+	Keyring Keyring
+	Service string
+	User    string
+}
+
+// NewKeyringTokenStore returns a KeyringTokenStore that stores the token in keyring under service/user.
+func NewKeyringTokenStore(keyring Keyring, service, user string) *KeyringTokenStore {
+	return &KeyringTokenStore{Keyring: keyring, Service: service, User: user}
+}
+
+func (s *KeyringTokenStore) Load(ctx context.Context) (*Token, error) {
+	// Keyring implementations don't agree on a sentinel "not found" error, so
+	// any lookup failure here is treated as a cache miss rather than a fatal
+	// error; a genuinely broken backend will surface on the Save call instead.
+	data, err := s.Keyring.Get(s.Service, s.User)
+	if err != nil {
+		return nil, nil
+	}
+
+	var token Token
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, errors.Wrap(err, "decode keyring token")
+	}
+
+	return &token, nil
+}
+
+func (s *KeyringTokenStore) Save(ctx context.Context, token *Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return errors.Wrap(err, "encode token")
+	}
+
+	if err := s.Keyring.Set(s.Service, s.User, string(data)); err != nil {
+		return errors.Wrap(err, "save keyring token")
+	}
+
+	return nil
+}
+
+func (s *KeyringTokenStore) Delete(ctx context.Context) error {
+	if err := s.Keyring.Delete(s.Service, s.User); err != nil {
+		return errors.Wrap(err, "delete keyring token")
+	}
+
+	return nil
+}